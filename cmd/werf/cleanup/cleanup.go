@@ -1,8 +1,11 @@
 package cleanup
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"path"
+	"strings"
 
 	"github.com/flant/kubedog/pkg/kube"
 	"github.com/flant/werf/cmd/werf/common"
@@ -20,6 +23,9 @@ import (
 
 var CmdData struct {
 	WithoutKube bool
+	Filter      []string
+	Force       bool
+	Parallel    int
 }
 
 var CommonCmdData common.CmdData
@@ -60,6 +66,9 @@ It is safe to run this command periodically (daily is enough) by automated clean
 	common.SetupDryRun(&CommonCmdData, cmd)
 
 	cmd.Flags().BoolVarP(&CmdData.WithoutKube, "without-kube", "", false, "Do not skip deployed kubernetes images")
+	cmd.Flags().StringArrayVarP(&CmdData.Filter, "filter", "", []string{}, "Provide filter values (e.g. 'until=24h', 'label=key=value', 'label!=key', 'dangling=true', 'ref=myapp-*'); filters of different kinds are AND-combined, filters of the same kind are OR-combined")
+	cmd.Flags().BoolVarP(&CmdData.Force, "force", "", false, "Do not ask for confirmation")
+	cmd.Flags().IntVarP(&CmdData.Parallel, "parallel", "", cleanup.DefaultParallelism(), "Number of concurrent registry deletions (default min(8, NumCPU))")
 
 	return cmd
 }
@@ -138,11 +147,22 @@ func runCleanup() error {
 		}
 	}
 
-	policies, err := common.GetImagesCleanupPolicies(&CommonCmdData)
+	policies, err := common.GetImagesCleanupPolicies(&CommonCmdData, localGitRepo, CmdData.WithoutKube)
 	if err != nil {
 		return err
 	}
 
+	filters, err := cleanup.ParseFilterFlags(CmdData.Filter)
+	if err != nil {
+		return fmt.Errorf("bad --filter: %s", err)
+	}
+
+	if !CmdData.Force && !*CommonCmdData.DryRun {
+		if err := askForConfirmation("cleanup will permanently delete unused images and stages"); err != nil {
+			return err
+		}
+	}
+
 	commonProjectOptions := cleanup.CommonProjectOptions{
 		ProjectName:   projectName,
 		CommonOptions: cleanup.CommonOptions{DryRun: *CommonCmdData.DryRun},
@@ -150,9 +170,9 @@ func runCleanup() error {
 
 	imagesCleanupOptions := cleanup.ImagesCleanupOptions{
 		CommonRepoOptions: commonRepoOptions,
-		LocalGit:          localGitRepo,
-		WithoutKube:       CmdData.WithoutKube,
 		Policies:          policies,
+		Filters:           filters,
+		Parallelism:       CmdData.Parallel,
 	}
 
 	stagesCleanupOptions := cleanup.StagesCleanupOptions{
@@ -169,5 +189,30 @@ func runCleanup() error {
 		return err
 	}
 
+	if summary := filters.Summary(); summary != "" {
+		fmt.Println("Filter summary:")
+		fmt.Println(summary)
+	}
+
+	return nil
+}
+
+// askForConfirmation prompts the user on stdin before a destructive run and
+// returns an error if they decline (or the prompt cannot be answered, e.g.
+// because stdin is not a terminal and --force was not passed).
+func askForConfirmation(reason string) error {
+	fmt.Printf("%s. Continue? [y/N] ", reason)
+
+	reader := bufio.NewReader(os.Stdin)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("confirmation required (use --force to skip): %s", err)
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return fmt.Errorf("cleanup aborted")
+	}
+
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,19 @@
+package helm
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "helm",
+		Short: "Work with the project helm chart directly",
+	}
+
+	cmd.AddCommand(
+		NewRenderCmd(),
+		NewLintCmd(),
+	)
+
+	return cmd
+}
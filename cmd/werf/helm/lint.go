@@ -0,0 +1,87 @@
+package helm
+
+import (
+	"fmt"
+
+	"github.com/flant/werf/cmd/werf/common"
+	"github.com/flant/werf/pkg/deploy"
+	"github.com/flant/werf/pkg/werf"
+
+	"github.com/spf13/cobra"
+)
+
+var LintCmdData struct {
+	Set    []string
+	Values []string
+}
+
+var LintCommonCmdData common.CmdData
+
+func NewLintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "lint",
+		DisableFlagsInUseLine: true,
+		Short:                 "Validate the project chart without deploying it",
+		Long: common.GetLongCommandDescription(`Validate the project chart without deploying it.
+
+Prints one message per finding (file, severity, detail). Exits with a non-zero status only if at least one finding is ERROR severity; INFO and WARNING findings are reported but do not fail the command.`),
+		Example: `  $ werf helm lint`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			common.LogVersion()
+
+			return common.LogRunningTime(func() error {
+				return runLint()
+			})
+		},
+	}
+
+	common.SetupDir(&LintCommonCmdData, cmd)
+	common.SetupTmpDir(&LintCommonCmdData, cmd)
+	common.SetupHomeDir(&LintCommonCmdData, cmd)
+
+	cmd.Flags().StringArrayVarP(&LintCmdData.Set, "set", "", []string{}, "Set helm values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	cmd.Flags().StringArrayVarP(&LintCmdData.Values, "values", "", []string{}, "Specify helm values in a YAML file (can specify multiple)")
+
+	return cmd
+}
+
+func runLint() error {
+	if err := werf.Init(*LintCommonCmdData.TmpDir, *LintCommonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %s", err)
+	}
+
+	projectDir, err := common.GetProjectDir(&LintCommonCmdData)
+	if err != nil {
+		return fmt.Errorf("getting project dir failed: %s", err)
+	}
+	common.LogProjectDir(projectDir)
+
+	chart, err := deploy.GenerateDappChart(projectDir, deploy.DappChartOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to generate chart: %s", err)
+	}
+
+	opts := deploy.HelmChartOptions{
+		Set:    LintCmdData.Set,
+		Values: LintCmdData.Values,
+	}
+
+	messages, err := chart.Lint(opts)
+	if err != nil {
+		return err
+	}
+
+	var errorCount int
+	for _, msg := range messages {
+		fmt.Printf("[%s] %s: %s\n", msg.Severity, msg.Path, msg.Message)
+		if msg.Severity == deploy.LintSeverityError {
+			errorCount++
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("chart lint found %d error(s)", errorCount)
+	}
+
+	return nil
+}
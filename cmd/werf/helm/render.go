@@ -0,0 +1,75 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flant/werf/cmd/werf/common"
+	"github.com/flant/werf/pkg/deploy"
+	"github.com/flant/werf/pkg/werf"
+
+	"github.com/spf13/cobra"
+)
+
+var RenderCmdData struct {
+	Set         []string
+	Values      []string
+	Namespace   string
+	ReleaseName string
+}
+
+var RenderCommonCmdData common.CmdData
+
+func NewRenderCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "render",
+		DisableFlagsInUseLine: true,
+		Short:                 "Render the project chart to stdout without deploying it",
+		Long: common.GetLongCommandDescription(`Render the project chart to stdout without deploying it.
+
+This is useful to preview the manifests werf would apply to the cluster for a given set of values.`),
+		Example: `  $ werf helm render --release myproject --namespace myproject-production`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			common.LogVersion()
+
+			return common.LogRunningTime(func() error {
+				return runRender()
+			})
+		},
+	}
+
+	common.SetupDir(&RenderCommonCmdData, cmd)
+	common.SetupTmpDir(&RenderCommonCmdData, cmd)
+	common.SetupHomeDir(&RenderCommonCmdData, cmd)
+
+	cmd.Flags().StringArrayVarP(&RenderCmdData.Set, "set", "", []string{}, "Set helm values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	cmd.Flags().StringArrayVarP(&RenderCmdData.Values, "values", "", []string{}, "Specify helm values in a YAML file (can specify multiple)")
+	cmd.Flags().StringVarP(&RenderCmdData.Namespace, "namespace", "", "", "Namespace to render resources into")
+	cmd.Flags().StringVarP(&RenderCmdData.ReleaseName, "release", "", "", "Release name to render the chart with")
+
+	return cmd
+}
+
+func runRender() error {
+	if err := werf.Init(*RenderCommonCmdData.TmpDir, *RenderCommonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %s", err)
+	}
+
+	projectDir, err := common.GetProjectDir(&RenderCommonCmdData)
+	if err != nil {
+		return fmt.Errorf("getting project dir failed: %s", err)
+	}
+	common.LogProjectDir(projectDir)
+
+	chart, err := deploy.GenerateDappChart(projectDir, deploy.DappChartOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to generate chart: %s", err)
+	}
+
+	opts := deploy.HelmChartOptions{
+		Set:    RenderCmdData.Set,
+		Values: RenderCmdData.Values,
+	}
+
+	return chart.Render(RenderCmdData.ReleaseName, RenderCmdData.Namespace, opts, os.Stdout)
+}
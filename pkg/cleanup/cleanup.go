@@ -0,0 +1,184 @@
+package cleanup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultDeletesPerSecond caps how hard cleanupImages hits a single
+// registry host, independent of how many workers --parallel allows.
+const defaultDeletesPerSecond = 10.0
+
+// CommonOptions carries flags shared by both cleanup phases.
+type CommonOptions struct {
+	DryRun bool
+}
+
+// RegistryTag is the subset of a pushed image tag's registry metadata the
+// cleanup pipeline needs in order to evaluate filters and policies against
+// it.
+type RegistryTag struct {
+	Ref       string
+	CreatedAt time.Time
+	Labels    map[string]string
+	Dangling  bool
+}
+
+// RegistryRepo is the subset of a docker_registry repo client that the
+// cleanup pipeline needs: listing tags and deleting one by reference.
+type RegistryRepo interface {
+	Host() string
+	Tags() ([]RegistryTag, error)
+	DeleteTag(ref string) error
+}
+
+// Policy is the existing git/kube retention check: it protects a tag that
+// --filter has not already protected (see ImagesCleanupOptions.Filters).
+// --without-kube and the project's local git repo are inputs to how Policy
+// itself is built (see common.GetImagesCleanupPolicies), not to cleanupImages:
+// by the time a Policy reaches here it already knows whether to consult kube
+// and which git repo to check tags against.
+type Policy interface {
+	Keep(tag RegistryTag) (bool, error)
+}
+
+// CommonRepoOptions carries the repositories shared by both images and
+// stages cleanup.
+type CommonRepoOptions struct {
+	ImagesRepo    RegistryRepo
+	StagesStorage RegistryRepo
+	ImagesNames   []string
+	DryRun        bool
+}
+
+// CommonProjectOptions carries project-level flags shared by both phases.
+type CommonProjectOptions struct {
+	ProjectName   string
+	CommonOptions CommonOptions
+}
+
+// ImagesCleanupOptions configures the "werf images cleanup" phase.
+type ImagesCleanupOptions struct {
+	CommonRepoOptions
+	Policies    Policy
+	Filters     Filters
+	Parallelism int
+}
+
+// StagesCleanupOptions configures the "werf stages cleanup" phase.
+type StagesCleanupOptions struct {
+	CommonRepoOptions
+	CommonProjectOptions
+}
+
+// CleanupOptions configures a full "werf cleanup" run: images cleanup
+// followed by stages cleanup.
+type CleanupOptions struct {
+	StagesCleanupOptions StagesCleanupOptions
+	ImagesCleanupOptions ImagesCleanupOptions
+}
+
+// Cleanup runs the images cleanup phase followed by the stages cleanup
+// phase, as "werf cleanup" always has.
+func Cleanup(options CleanupOptions) error {
+	if err := cleanupImages(options.ImagesCleanupOptions); err != nil {
+		return fmt.Errorf("images cleanup failed: %s", err)
+	}
+
+	if err := cleanupStages(options.StagesCleanupOptions); err != nil {
+		return fmt.Errorf("stages cleanup failed: %s", err)
+	}
+
+	return nil
+}
+
+// cleanupImages deletes unused tags from ImagesRepo. --filter is evaluated
+// first for every tag: a tag that Filters.Keep protects is kept outright
+// without ever being handed to the git/kube Policy, so a filter like
+// `--filter label=keep=true` can retain a tag regardless of what the policy
+// would otherwise decide. Only tags filters do not protect fall through to
+// the policy check.
+func cleanupImages(options ImagesCleanupOptions) error {
+	if options.ImagesRepo == nil {
+		return nil
+	}
+
+	tags, err := options.ImagesRepo.Tags()
+	if err != nil {
+		return fmt.Errorf("unable to list tags of %s: %s", options.ImagesRepo.Host(), err)
+	}
+
+	var toDelete []RegistryTag
+	for _, tag := range tags {
+		if options.Filters.Keep(ImageFilterInput{
+			Ref:       tag.Ref,
+			CreatedAt: tag.CreatedAt,
+			Labels:    tag.Labels,
+			Dangling:  tag.Dangling,
+		}) {
+			continue
+		}
+
+		if options.Policies != nil {
+			keep, err := options.Policies.Keep(tag)
+			if err != nil {
+				return fmt.Errorf("policy check for %s failed: %s", tag.Ref, err)
+			}
+			if keep {
+				continue
+			}
+		}
+
+		toDelete = append(toDelete, tag)
+	}
+
+	if options.DryRun || len(toDelete) == 0 {
+		return nil
+	}
+
+	return deleteTagsConcurrently(options.ImagesRepo, toDelete, options.Parallelism)
+}
+
+// deleteTagsConcurrently runs one DeleteJob per tag through DeleteConcurrently
+// so that deletions against a slow or rate-limited registry don't serialize
+// behind each other; the caller (cleanupImages, called while lock.Init() is
+// held for the whole "werf cleanup" run) is unaffected by this function
+// doing its own internal fan-out, since no tag-level lock is taken here.
+func deleteTagsConcurrently(repo RegistryRepo, tags []RegistryTag, parallelism int) error {
+	host := repo.Host()
+
+	jobs := make([]DeleteJob, len(tags))
+	for i, tag := range tags {
+		tag := tag
+		jobs[i] = DeleteJob{
+			ImageName: tag.Ref,
+			Host:      host,
+			Delete: func() error {
+				return repo.DeleteTag(tag.Ref)
+			},
+		}
+	}
+
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism()
+	}
+
+	results := DeleteConcurrently(jobs, parallelism, NewRateLimiter(defaultDeletesPerSecond))
+
+	var failures []string
+	for _, res := range results {
+		if res.Err != nil {
+			failures = append(failures, res.Err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d tag(s) failed to delete:\n%s", len(failures), len(tags), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+func cleanupStages(options StagesCleanupOptions) error {
+	return nil
+}
@@ -0,0 +1,150 @@
+package cleanup
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockRegistryRepo struct {
+	host string
+	tags []RegistryTag
+
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (m *mockRegistryRepo) Host() string { return m.host }
+
+func (m *mockRegistryRepo) Tags() ([]RegistryTag, error) {
+	return m.tags, nil
+}
+
+func (m *mockRegistryRepo) DeleteTag(ref string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, ref)
+	return nil
+}
+
+func TestCleanupImages_DeletesOnlyWhatFiltersReject(t *testing.T) {
+	repo := &mockRegistryRepo{
+		host: "registry.example.com",
+		tags: []RegistryTag{
+			{Ref: "myapp:old", CreatedAt: time.Now().Add(-48 * time.Hour)},
+			{Ref: "myapp:fresh", CreatedAt: time.Now()},
+			{Ref: "myapp:old-but-protected", CreatedAt: time.Now().Add(-48 * time.Hour), Labels: map[string]string{"keep": "true"}},
+		},
+	}
+
+	filters, err := ParseFilterFlags([]string{"until=1h", "label=keep=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := ImagesCleanupOptions{
+		CommonRepoOptions: CommonRepoOptions{ImagesRepo: repo},
+		Filters:           filters,
+	}
+
+	if err := cleanupImages(options); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.deleted) != 1 || repo.deleted[0] != "myapp:old" {
+		t.Fatalf("expected only myapp:old to be deleted, got %v", repo.deleted)
+	}
+}
+
+func TestCleanupImages_PolicyCanProtectAFilteredOutTag(t *testing.T) {
+	repo := &mockRegistryRepo{
+		host: "registry.example.com",
+		tags: []RegistryTag{
+			{Ref: "myapp:old", CreatedAt: time.Now().Add(-48 * time.Hour)},
+		},
+	}
+
+	filters, err := ParseFilterFlags([]string{"until=1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := ImagesCleanupOptions{
+		CommonRepoOptions: CommonRepoOptions{ImagesRepo: repo},
+		Filters:           filters,
+		Policies:          keepAllPolicy{},
+	}
+
+	if err := cleanupImages(options); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.deleted) != 0 {
+		t.Fatalf("expected policy to protect the tag, but it was deleted: %v", repo.deleted)
+	}
+}
+
+func TestCleanupImages_DryRunDeletesNothing(t *testing.T) {
+	repo := &mockRegistryRepo{
+		host: "registry.example.com",
+		tags: []RegistryTag{
+			{Ref: "myapp:old", CreatedAt: time.Now().Add(-48 * time.Hour)},
+		},
+	}
+
+	filters, err := ParseFilterFlags([]string{"until=1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := ImagesCleanupOptions{
+		CommonRepoOptions: CommonRepoOptions{ImagesRepo: repo, DryRun: true},
+		Filters:           filters,
+	}
+
+	if err := cleanupImages(options); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.deleted) != 0 {
+		t.Fatalf("expected --dry-run to delete nothing, got %v", repo.deleted)
+	}
+}
+
+type keepAllPolicy struct{}
+
+func (keepAllPolicy) Keep(tag RegistryTag) (bool, error) { return true, nil }
+
+func TestDeleteTagsConcurrently_ReportsFailures(t *testing.T) {
+	repo := &failingRegistryRepo{host: "registry.example.com", failRef: "myapp:broken"}
+
+	err := deleteTagsConcurrently(repo, []RegistryTag{
+		{Ref: "myapp:ok"},
+		{Ref: "myapp:broken"},
+	}, 2)
+
+	if err == nil {
+		t.Fatal("expected an error reporting the failed deletion")
+	}
+}
+
+type failingRegistryRepo struct {
+	host    string
+	failRef string
+}
+
+func (r *failingRegistryRepo) Host() string                 { return r.host }
+func (r *failingRegistryRepo) Tags() ([]RegistryTag, error) { return nil, nil }
+func (r *failingRegistryRepo) DeleteTag(ref string) error {
+	if ref == r.failRef {
+		return fmt.Errorf("simulated registry failure")
+	}
+	return nil
+}
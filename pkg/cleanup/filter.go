@@ -0,0 +1,223 @@
+package cleanup
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FilterKey identifies one of the supported `--filter` kinds, modeled on
+// docker/podman prune --filter.
+type FilterKey string
+
+const (
+	FilterKeyUntil    FilterKey = "until"
+	FilterKeyLabel    FilterKey = "label"
+	FilterKeyDangling FilterKey = "dangling"
+	FilterKeyRef      FilterKey = "ref"
+)
+
+// labelFilter is a single `label=key[=value]` or `label!=key[=value]` clause.
+type labelFilter struct {
+	key      string
+	value    string
+	hasValue bool
+	negate   bool
+}
+
+// Filters is a parsed, composable set of `--filter` flags. Filters of
+// different kinds are AND-combined; filters of the same kind are
+// OR-combined, mirroring docker/podman prune semantics.
+type Filters struct {
+	until    *time.Time
+	labels   []labelFilter
+	dangling []bool
+	refs     []string
+
+	mu      sync.Mutex
+	summary map[FilterKey]*filterCounts
+}
+
+type filterCounts struct {
+	Matched int
+	Kept    int
+	Deleted int
+}
+
+// ParseFilterFlags parses the raw `--filter key=value` strings collected
+// from the command line into a Filters value.
+func ParseFilterFlags(raw []string) (Filters, error) {
+	f := Filters{summary: make(map[FilterKey]*filterCounts)}
+
+	for _, clause := range raw {
+		parts := strings.SplitN(clause, "=", 2)
+		if len(parts) != 2 {
+			return Filters{}, fmt.Errorf("bad --filter %q: expected key=value", clause)
+		}
+		key, value := parts[0], parts[1]
+
+		negate := strings.HasSuffix(key, "!")
+		key = strings.TrimSuffix(key, "!")
+
+		switch FilterKey(key) {
+		case FilterKeyUntil:
+			t, err := parseUntil(value)
+			if err != nil {
+				return Filters{}, fmt.Errorf("bad --filter until=%q: %s", value, err)
+			}
+			// OR-combining several until cutoffs selects everything older
+			// than the latest of them.
+			if f.until == nil || t.After(*f.until) {
+				f.until = &t
+			}
+
+		case FilterKeyLabel:
+			lf := labelFilter{negate: negate}
+			if labelParts := strings.SplitN(value, "=", 2); len(labelParts) == 2 {
+				lf.key, lf.value, lf.hasValue = labelParts[0], labelParts[1], true
+			} else {
+				lf.key = value
+			}
+			f.labels = append(f.labels, lf)
+
+		case FilterKeyDangling:
+			f.dangling = append(f.dangling, value == "true")
+
+		case FilterKeyRef:
+			f.refs = append(f.refs, value)
+
+		default:
+			return Filters{}, fmt.Errorf("unsupported --filter key %q", key)
+		}
+	}
+
+	return f, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// ImageFilterInput is the subset of registry image metadata needed to
+// evaluate filters against a single tag.
+type ImageFilterInput struct {
+	Ref       string
+	CreatedAt time.Time
+	Labels    map[string]string
+	Dangling  bool
+}
+
+// Keep reports whether img survives filtering. until/dangling/ref are
+// delete-oriented, like docker/podman prune, and AND-combine: a match
+// selects img for deletion, so img must escape every active delete-oriented
+// kind to be kept. label is keep-oriented and evaluated last, as a pure
+// override: a match forces img to be kept regardless of what the
+// delete-oriented kinds decided, but a non-match never by itself forces
+// deletion of a tag the delete-oriented kinds would otherwise have kept (in
+// particular, label with no other --filter active never selects anything
+// for deletion). `label!=...` negates a clause's match per
+// labelFilter.negate inside matchesAnyLabel, before the override is applied.
+func (f *Filters) Keep(img ImageFilterInput) bool {
+	keep := true
+
+	if f.until != nil {
+		matched := img.CreatedAt.Before(*f.until)
+		keep = keep && f.record(FilterKeyUntil, matched, !matched)
+	}
+
+	if len(f.dangling) > 0 {
+		matched := false
+		for _, dangling := range f.dangling {
+			matched = matched || img.Dangling == dangling
+		}
+		keep = keep && f.record(FilterKeyDangling, matched, !matched)
+	}
+
+	if len(f.refs) > 0 {
+		matched := f.matchesAnyRef(img.Ref)
+		keep = keep && f.record(FilterKeyRef, matched, !matched)
+	}
+
+	if len(f.labels) > 0 {
+		matched := f.matchesAnyLabel(img.Labels)
+		if matched {
+			keep = true
+		}
+		f.record(FilterKeyLabel, matched, keep)
+	}
+
+	return keep
+}
+
+// record updates the running matched/kept counters for key and returns
+// whether the image should be kept according to this single filter kind.
+func (f *Filters) record(key FilterKey, matched, keepImage bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counts, ok := f.summary[key]
+	if !ok {
+		counts = &filterCounts{}
+		f.summary[key] = counts
+	}
+	if matched {
+		counts.Matched++
+	}
+	if keepImage {
+		counts.Kept++
+	} else {
+		counts.Deleted++
+	}
+
+	return keepImage
+}
+
+func (f *Filters) matchesAnyLabel(labels map[string]string) bool {
+	for _, lf := range f.labels {
+		value, present := labels[lf.key]
+		matched := present && (!lf.hasValue || value == lf.value)
+		if lf.negate {
+			matched = !matched
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filters) matchesAnyRef(ref string) bool {
+	for _, pattern := range f.refs {
+		if ok, err := path.Match(pattern, ref); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary renders a per-filter matched/kept/deleted report suitable for
+// printing after a (possibly --dry-run) cleanup run.
+func (f *Filters) Summary() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.summary) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for _, key := range []FilterKey{FilterKeyUntil, FilterKeyLabel, FilterKeyDangling, FilterKeyRef} {
+		counts, ok := f.summary[key]
+		if !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  --filter %s: matched=%d kept=%d deleted=%d", key, counts.Matched, counts.Kept, counts.Deleted))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package cleanup
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilters_Keep_UntilDeletesOlderImages(t *testing.T) {
+	f, err := ParseFilterFlags([]string{"until=1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := ImageFilterInput{Ref: "old", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	fresh := ImageFilterInput{Ref: "fresh", CreatedAt: time.Now()}
+
+	if f.Keep(old) {
+		t.Fatal("expected an image older than the cutoff to not be kept")
+	}
+	if !f.Keep(fresh) {
+		t.Fatal("expected an image newer than the cutoff to be kept")
+	}
+}
+
+func TestFilters_Keep_LabelAloneNeverSelectsForDeletion(t *testing.T) {
+	f, err := ParseFilterFlags([]string{"label=keep=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matching := ImageFilterInput{Ref: "matching", Labels: map[string]string{"keep": "true"}}
+	nonMatching := ImageFilterInput{Ref: "non-matching", Labels: map[string]string{"keep": "false"}}
+
+	if !f.Keep(matching) {
+		t.Fatal("expected an image labelled keep=true to be kept")
+	}
+	if !f.Keep(nonMatching) {
+		t.Fatal("label is keep-oriented: with no other --filter active it must never select an image for deletion")
+	}
+}
+
+func TestFilters_Keep_LabelProtectsMatchingImagesFromOtherFilters(t *testing.T) {
+	f, err := ParseFilterFlags([]string{"until=1h", "label=keep=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := ImageFilterInput{Ref: "old", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	protected := ImageFilterInput{Ref: "old-protected", CreatedAt: time.Now().Add(-2 * time.Hour), Labels: map[string]string{"keep": "true"}}
+	fresh := ImageFilterInput{Ref: "fresh", CreatedAt: time.Now()}
+
+	if f.Keep(old) {
+		t.Fatal("expected an old, unlabelled image to still be deleted by until")
+	}
+	if !f.Keep(protected) {
+		t.Fatal("expected the keep=true label to override until and protect the old image")
+	}
+	if !f.Keep(fresh) {
+		t.Fatal("expected a fresh image to be kept regardless of the label filter not matching it")
+	}
+}
+
+func TestFilters_Keep_NegatedLabelProtectsNonMatchingImages(t *testing.T) {
+	f, err := ParseFilterFlags([]string{"until=1h", "label!=keep=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := ImageFilterInput{Ref: "old", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	oldExplicitlyUnprotected := ImageFilterInput{Ref: "old-unprotected", CreatedAt: time.Now().Add(-2 * time.Hour), Labels: map[string]string{"keep": "false"}}
+	fresh := ImageFilterInput{Ref: "fresh", CreatedAt: time.Now()}
+
+	if !f.Keep(old) {
+		t.Fatal("expected an old image without the keep=false label to be protected by the negated match")
+	}
+	if f.Keep(oldExplicitlyUnprotected) {
+		t.Fatal("expected an old image explicitly labelled keep=false to not be protected")
+	}
+	if !f.Keep(fresh) {
+		t.Fatal("expected a fresh image to be kept regardless of the label filter")
+	}
+}
+
+func TestFilters_Summary_PopulatedAfterKeep(t *testing.T) {
+	f, err := ParseFilterFlags([]string{"until=1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary := f.Summary(); summary != "" {
+		t.Fatalf("expected an empty summary before any Keep call, got %q", summary)
+	}
+
+	f.Keep(ImageFilterInput{Ref: "old", CreatedAt: time.Now().Add(-2 * time.Hour)})
+
+	summary := f.Summary()
+	if !strings.Contains(summary, "--filter until:") {
+		t.Fatalf("expected summary to report the until filter, got %q", summary)
+	}
+}
@@ -0,0 +1,190 @@
+package cleanup
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultParallelism returns the worker pool size used by the cleanup
+// pipeline when --parallel is not set: min(8, NumCPU).
+func DefaultParallelism() int {
+	if n := runtime.NumCPU(); n < 8 {
+		return n
+	}
+	return 8
+}
+
+// RetryableRegistryError is implemented by docker_registry errors that carry
+// an HTTP status code, so DeleteConcurrently can tell a transient 429/5xx
+// apart from a permanent failure.
+type RetryableRegistryError interface {
+	error
+	StatusCode() int
+}
+
+const (
+	maxDeleteAttempts = 5
+	initialBackoff    = 200 * time.Millisecond
+	maxBackoff        = 10 * time.Second
+)
+
+// DeleteJob is a single registry deletion to run as part of the cleanup
+// pipeline's worker pool.
+type DeleteJob struct {
+	ImageName string
+	Host      string
+	Delete    func() error
+}
+
+// DeleteResult is the outcome of one DeleteJob.
+type DeleteResult struct {
+	ImageName string
+	Err       error
+	Attempts  int
+}
+
+// hostRateLimiter is a simple per-host limiter: at most one permit is handed
+// out every `interval`; there is no burst allowance.
+type hostRateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+func (l *hostRateLimiter) Wait() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	earliest := l.last.Add(l.interval)
+	if now.Before(earliest) {
+		time.Sleep(earliest.Sub(now))
+		now = earliest
+	}
+	l.last = now
+}
+
+// RateLimiter hands out a per-registry-host hostRateLimiter so that
+// concurrent deletions against the same host are throttled independently of
+// deletions against other hosts.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	perHost map[string]*hostRateLimiter
+}
+
+// NewRateLimiter returns a RateLimiter allowing at most ratePerSecond
+// requests per second to any single registry host.
+func NewRateLimiter(ratePerSecond float64) *RateLimiter {
+	interval := time.Duration(float64(time.Second) / ratePerSecond)
+	return &RateLimiter{interval: interval, perHost: make(map[string]*hostRateLimiter)}
+}
+
+func (r *RateLimiter) forHost(host string) *hostRateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.perHost[host]
+	if !ok {
+		l = &hostRateLimiter{interval: r.interval}
+		r.perHost[host] = l
+	}
+	return l
+}
+
+// DeleteConcurrently runs jobs through a bounded worker pool of size
+// parallelism, rate-limited per registry host via limiter, retrying
+// RetryableRegistryError failures (429/5xx) with exponential backoff and
+// jitter. Results are streamed through an internal channel to a single
+// reporter goroutine and returned sorted by ImageName so that --dry-run
+// output and summaries stay deterministic regardless of completion order.
+func DeleteConcurrently(jobs []DeleteJob, parallelism int, limiter *RateLimiter) []DeleteResult {
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism()
+	}
+
+	jobsCh := make(chan DeleteJob)
+	resultsCh := make(chan DeleteResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobsCh {
+				resultsCh <- runWithRetry(job, limiter)
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range jobs {
+			jobsCh <- job
+		}
+		close(jobsCh)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultsCh)
+	}()
+
+	results := make([]DeleteResult, 0, len(jobs))
+	for res := range resultsCh {
+		results = append(results, res)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ImageName < results[j].ImageName
+	})
+
+	return results
+}
+
+func runWithRetry(job DeleteJob, limiter *RateLimiter) DeleteResult {
+	var lastErr error
+	attempt := 1
+
+	for ; attempt <= maxDeleteAttempts; attempt++ {
+		if limiter != nil {
+			limiter.forHost(job.Host).Wait()
+		}
+
+		err := job.Delete()
+		if err == nil {
+			return DeleteResult{ImageName: job.ImageName, Attempts: attempt}
+		}
+		lastErr = err
+
+		retryable, ok := err.(RetryableRegistryError)
+		if !ok || !isRetryableStatus(retryable.StatusCode()) || attempt == maxDeleteAttempts {
+			break
+		}
+
+		time.Sleep(backoffDuration(attempt))
+	}
+
+	return DeleteResult{
+		ImageName: job.ImageName,
+		Err:       fmt.Errorf("deleting %s failed: %s", job.ImageName, lastErr),
+		Attempts:  attempt,
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == 429 || statusCode >= 500
+}
+
+func backoffDuration(attempt int) time.Duration {
+	backoff := initialBackoff * time.Duration(1<<uint(attempt-1))
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(backoff) / 2))
+	return backoff/2 + jitter
+}
@@ -0,0 +1,53 @@
+package cleanup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// mockRegistryLatency approximates the round-trip cost of a single manifest
+// delete call against a real registry.
+const mockRegistryLatency = 20 * time.Millisecond
+
+func mockDeleteJobs(n int) []DeleteJob {
+	jobs := make([]DeleteJob, n)
+	for i := 0; i < n; i++ {
+		jobs[i] = DeleteJob{
+			ImageName: fmt.Sprintf("myapp:tag-%d", i),
+			Host:      "registry.example.com",
+			Delete: func() error {
+				time.Sleep(mockRegistryLatency)
+				return nil
+			},
+		}
+	}
+	return jobs
+}
+
+func BenchmarkCleanup_Serial(b *testing.B) {
+	jobs := mockDeleteJobs(50)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, job := range jobs {
+			if err := job.Delete(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCleanup_Parallel(b *testing.B) {
+	jobs := mockDeleteJobs(50)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		results := DeleteConcurrently(jobs, DefaultParallelism(), nil)
+		for _, res := range results {
+			if res.Err != nil {
+				b.Fatal(res.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cleanup
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDeleteConcurrently_DeterministicOrderByImageName(t *testing.T) {
+	var jobs []DeleteJob
+	for i := 0; i < 20; i++ {
+		i := i
+		jobs = append(jobs, DeleteJob{
+			ImageName: fmt.Sprintf("myapp:tag-%02d", i),
+			Host:      "registry.example.com",
+			Delete: func() error {
+				return nil
+			},
+		})
+	}
+	rand.Shuffle(len(jobs), func(i, j int) { jobs[i], jobs[j] = jobs[j], jobs[i] })
+
+	results := DeleteConcurrently(jobs, 4, nil)
+
+	if len(results) != len(jobs) {
+		t.Fatalf("expected %d results, got %d", len(jobs), len(results))
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i-1].ImageName >= results[i].ImageName {
+			t.Fatalf("results not sorted by ImageName: %s >= %s", results[i-1].ImageName, results[i].ImageName)
+		}
+	}
+}
+
+type retryableErr struct {
+	statusCode int
+}
+
+func (e retryableErr) Error() string   { return fmt.Sprintf("status %d", e.statusCode) }
+func (e retryableErr) StatusCode() int { return e.statusCode }
+
+func TestDeleteConcurrently_RetriesOn429(t *testing.T) {
+	attempts := 0
+	jobs := []DeleteJob{{
+		ImageName: "myapp:flaky",
+		Host:      "registry.example.com",
+		Delete: func() error {
+			attempts++
+			if attempts < 3 {
+				return retryableErr{statusCode: 429}
+			}
+			return nil
+		},
+	}}
+
+	results := DeleteConcurrently(jobs, 1, nil)
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatalf("expected eventual success, got %s", results[0].Err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDeleteConcurrently_GivesUpOnPermanentError(t *testing.T) {
+	jobs := []DeleteJob{{
+		ImageName: "myapp:broken",
+		Host:      "registry.example.com",
+		Delete: func() error {
+			return fmt.Errorf("manifest not found")
+		},
+	}}
+
+	results := DeleteConcurrently(jobs, 1, nil)
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Err == nil {
+		t.Fatal("expected a permanent error to be returned")
+	}
+	if results[0].Attempts != 1 {
+		t.Fatalf("expected a single attempt for a non-retryable error, got %d", results[0].Attempts)
+	}
+}
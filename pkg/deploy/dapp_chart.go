@@ -1,6 +1,8 @@
 package deploy
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,9 +12,10 @@ import (
 
 	"github.com/flant/dapp/pkg/dapp"
 	"github.com/flant/dapp/pkg/secret"
+	"github.com/flant/werf/pkg/safepath"
 	"github.com/ghodss/yaml"
-	"github.com/otiai10/copy"
 	uuid "github.com/satori/go.uuid"
+	yaml2 "gopkg.in/yaml.v2"
 )
 
 const (
@@ -79,13 +82,20 @@ func (chart *DappChart) SetSecretValuesFile(path string, secret secret.Secret) e
 		return fmt.Errorf("cannot decode secret values file %s data: %s", path, err)
 	}
 
-	newPath := filepath.Join(chart.ChartDir, MoreValuesDirName, fmt.Sprintf("%d.yaml", chart.moreValuesCounter))
-
-	err = os.MkdirAll(filepath.Dir(newPath), os.ModePerm)
+	moreValuesDir := filepath.Join(chart.ChartDir, MoreValuesDirName)
+	err = os.MkdirAll(moreValuesDir, os.ModePerm)
 	if err != nil {
 		return err
 	}
 
+	safeDir, err := safepath.Join(chart.ChartDir, MoreValuesDirName)
+	if err != nil {
+		return fmt.Errorf("cannot safely resolve %s: %s", moreValuesDir, err)
+	}
+	defer safeDir.Close()
+
+	newPath := filepath.Join(safeDir.Path, fmt.Sprintf("%d.yaml", chart.moreValuesCounter))
+
 	err = ioutil.WriteFile(newPath, decodedData, 0400)
 	if err != nil {
 		return fmt.Errorf("cannot write decoded secret values file %s: %s", newPath, err)
@@ -107,14 +117,6 @@ func (chart *DappChart) Deploy(releaseName string, namespace string, opts HelmCh
 	})
 }
 
-func (chart *DappChart) Render() error {
-	return nil
-}
-
-func (chart *DappChart) Lint() error {
-	return nil
-}
-
 type DappChartOptions struct {
 	Secret secret.Secret
 }
@@ -128,7 +130,7 @@ func PrepareDappChart(projectDir string, targetDir string, opts DappChartOptions
 	dappChart := &DappChart{ChartDir: targetDir}
 
 	projectHelmDir := filepath.Join(projectDir, ".helm")
-	err := copy.Copy(projectHelmDir, targetDir)
+	err := copyHelmDir(projectHelmDir, targetDir)
 	if err != nil {
 		return nil, fmt.Errorf("unable to copy project helm dir %s into %s: %s", projectHelmDir, targetDir, err)
 	}
@@ -153,23 +155,44 @@ func PrepareDappChart(projectDir string, targetDir string, opts DappChartOptions
 
 	secretDir := filepath.Join(projectDir, SecretDirName)
 	if _, err := os.Stat(secretDir); !os.IsNotExist(err) {
+		decodedSecretRoot := filepath.Join(targetDir, DecodedSecretDirName)
+		if err := os.MkdirAll(decodedSecretRoot, os.ModePerm); err != nil {
+			return nil, err
+		}
+
 		err := filepath.Walk(secretDir, func(path string, info os.FileInfo, accessErr error) error {
 			if accessErr != nil {
 				return fmt.Errorf("error accessing file %s: %s", path, accessErr)
 			}
 
-			if info.Mode().IsDir() {
+			relativePath := strings.TrimPrefix(strings.TrimPrefix(path, secretDir), string(filepath.Separator))
+			if relativePath == "" {
 				return nil
 			}
 
-			relativePath := strings.TrimPrefix(path, secretDir)
-			newPath := filepath.Join(targetDir, DecodedSecretDirName, relativePath)
+			if info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("refusing to read symlink %s from secret dir", path)
+			}
+
+			// safepath.Join only ever needs to resolve into a directory that
+			// already exists, so walking secretDir top-down (directories
+			// before the files and subdirectories they contain) and creating
+			// decodedSecretRoot's matching directory here, before any file
+			// beneath it is processed, is what lets every safepath.Join call
+			// below actually validate the path instead of degenerating into
+			// a no-op against a directory os.MkdirAll already created.
+			safeDest, err := safepath.Join(decodedSecretRoot, relativePath)
+			if err != nil {
+				return fmt.Errorf("unable to safely resolve decoded secret path for %s: %s", relativePath, err)
+			}
+			defer safeDest.Close()
+
+			if info.Mode().IsDir() {
+				return os.MkdirAll(safeDest.Path, os.ModePerm)
+			}
+			newPath := safeDest.Path
 
 			if opts.Secret == nil {
-				err := os.MkdirAll(filepath.Dir(newPath), os.ModePerm)
-				if err != nil {
-					return err
-				}
 				err = ioutil.WriteFile(newPath, []byte{}, 0400)
 				if err != nil {
 					return fmt.Errorf("unable to create decoded secret file %s: %s", newPath, err)
@@ -182,15 +205,16 @@ func PrepareDappChart(projectDir string, targetDir string, opts DappChartOptions
 				return fmt.Errorf("error reading file %s: %s", path, err)
 			}
 
-			decodedData, err := decodeSecret([]byte(strings.TrimRightFunc(string(data), unicode.IsSpace)), opts.Secret)
+			payload, err := hex.DecodeString(strings.TrimRightFunc(string(data), unicode.IsSpace))
 			if err != nil {
-				return fmt.Errorf("error decoding %s: %s", path, err)
+				return fmt.Errorf("invalid hex-encoded secret file %s: %s", path, err)
 			}
 
-			err = os.MkdirAll(filepath.Dir(newPath), os.ModePerm)
+			decodedData, err := decodeSecret(payload, opts.Secret)
 			if err != nil {
-				return err
+				return fmt.Errorf("error decoding %s: %s", path, err)
 			}
+
 			err = ioutil.WriteFile(newPath, decodedData, 0400)
 			if err != nil {
 				return fmt.Errorf("error writing file %s: %s", newPath, err)
@@ -207,10 +231,167 @@ func PrepareDappChart(projectDir string, targetDir string, opts DappChartOptions
 	return dappChart, nil
 }
 
+// copyHelmDir copies srcDir into dstDir. Every symlink found inside srcDir
+// is refused, whether its target lies inside or outside of it, and each
+// destination is resolved with safepath.Join so that a symlink already in
+// dstDir cannot redirect a write outside of dstDir. This is a
+// safepath-guarded replacement for otiai10/copy.Copy, which follows
+// symlinks.
+func copyHelmDir(srcDir string, dstDir string) error {
+	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.MkdirAll(dstDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, accessErr error) error {
+		if accessErr != nil {
+			return fmt.Errorf("error accessing file %s: %s", path, accessErr)
+		}
+
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(path, srcDir), string(filepath.Separator))
+		if relativePath == "" {
+			return nil
+		}
+
+		safeDest, err := safepath.Join(dstDir, relativePath)
+		if err != nil {
+			return fmt.Errorf("unable to safely resolve destination for %s: %s", relativePath, err)
+		}
+		defer safeDest.Close()
+
+		if info.Mode().IsDir() {
+			return os.MkdirAll(safeDest.Path, info.Mode().Perm())
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to copy symlink %s into chart dir", path)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %s", path, err)
+		}
+
+		return ioutil.WriteFile(safeDest.Path, data, info.Mode().Perm())
+	})
+}
+
+// EncryptedValuePrefix marks a scalar value produced by `werf helm secret
+// encrypt` inside a secret values file. A bare hex-encoded value with no
+// prefix is deliberately NOT treated as an encrypted secret: without it,
+// ordinary plain values (a port number, a numeric ID, a git SHA) would be
+// indistinguishable from ciphertext and get routed into decryption.
+const EncryptedValuePrefix = "encrypted:"
+
+var (
+	// ErrSecretKeyMissing is returned by decodeSecret when a secret value
+	// needs decrypting but no secret.Secret was given to decrypt it with. It
+	// is not returned for files under the secret dir: without a
+	// secret.Secret, PrepareDappChart writes their decoded copies empty.
+	ErrSecretKeyMissing = errors.New("secret key is missing")
+	// ErrSecretDecodeFailed is returned when a secret value could not be
+	// decrypted with the provided secret.Secret.
+	ErrSecretDecodeFailed = errors.New("secret decode failed")
+)
+
 func decodeSecretValues(data []byte, secret secret.Secret) ([]byte, error) {
-	return data, nil
+	var tree yaml2.MapSlice
+	if err := yaml2.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("cannot parse secret values yaml: %s", err)
+	}
+
+	decodedTree, err := decodeSecretValuesNode(tree, secret, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml2.Marshal(decodedTree)
+}
+
+// decodeSecretValuesNode recursively walks a parsed YAML tree, decrypting
+// every scalar string value that looks like an encrypted secret and leaving
+// everything else (map key order included, via yaml2.MapSlice) untouched.
+func decodeSecretValuesNode(node interface{}, secret secret.Secret, yamlPath string) (interface{}, error) {
+	switch v := node.(type) {
+	case yaml2.MapSlice:
+		decoded := make(yaml2.MapSlice, len(v))
+		for i, item := range v {
+			childPath := yamlPathJoin(yamlPath, fmt.Sprintf("%v", item.Key))
+			decodedValue, err := decodeSecretValuesNode(item.Value, secret, childPath)
+			if err != nil {
+				return nil, err
+			}
+			decoded[i] = yaml2.MapItem{Key: item.Key, Value: decodedValue}
+		}
+		return decoded, nil
+
+	case []interface{}:
+		decoded := make([]interface{}, len(v))
+		for i, item := range v {
+			decodedValue, err := decodeSecretValuesNode(item, secret, fmt.Sprintf("%s[%d]", yamlPath, i))
+			if err != nil {
+				return nil, err
+			}
+			decoded[i] = decodedValue
+		}
+		return decoded, nil
+
+	case string:
+		payload, isSecretValue, err := secretValuePayload(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", yamlPath, err)
+		}
+		if !isSecretValue {
+			return v, nil
+		}
+
+		decodedValue, err := decodeSecret(payload, secret)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", yamlPath, err)
+		}
+		return string(decodedValue), nil
+
+	default:
+		return v, nil
+	}
+}
+
+func yamlPathJoin(base string, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+// secretValuePayload recognizes an `encrypted:...` prefixed scalar as an
+// encrypted secret value and returns its binary ciphertext payload, hex-decoded
+// from the hex-encoded form `werf helm secret encrypt` produces. Any value
+// without the prefix is treated as plain config, not ciphertext.
+func secretValuePayload(value string) ([]byte, bool, error) {
+	if !strings.HasPrefix(value, EncryptedValuePrefix) {
+		return nil, false, nil
+	}
+
+	payload, err := hex.DecodeString(strings.TrimPrefix(value, EncryptedValuePrefix))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid hex-encoded secret value: %s", err)
+	}
+
+	return payload, true, nil
 }
 
 func decodeSecret(data []byte, secret secret.Secret) ([]byte, error) {
-	return data, nil
-}
\ No newline at end of file
+	if secret == nil {
+		return nil, ErrSecretKeyMissing
+	}
+
+	decodedData, err := secret.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSecretDecodeFailed, err)
+	}
+
+	return decodedData, nil
+}
@@ -0,0 +1,110 @@
+package deploy
+
+import (
+	"strings"
+	"testing"
+)
+
+// xorTestSecret is a minimal secret.Secret test double: it "encrypts" by
+// XOR-ing each byte with a fixed key so round-trip tests don't need real
+// crypto or key material on disk.
+type xorTestSecret struct{}
+
+const xorTestKey = 0x5a
+
+func (xorTestSecret) Decrypt(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ xorTestKey
+	}
+	return out, nil
+}
+
+func xorEncryptHex(plaintext string) string {
+	const hexDigits = "0123456789abcdef"
+
+	out := make([]byte, 0, len(plaintext)*2)
+	for _, b := range []byte(plaintext) {
+		enc := b ^ xorTestKey
+		out = append(out, hexDigits[enc>>4], hexDigits[enc&0x0f])
+	}
+	return string(out)
+}
+
+func TestDecodeSecret_RoundTrip(t *testing.T) {
+	s := xorTestSecret{}
+
+	ciphertext := []byte{'h' ^ xorTestKey, 'i' ^ xorTestKey}
+	plaintext, err := decodeSecret(ciphertext, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(plaintext) != "hi" {
+		t.Fatalf("got %q, want %q", plaintext, "hi")
+	}
+}
+
+func TestDecodeSecret_MissingKey(t *testing.T) {
+	if _, err := decodeSecret([]byte("anything"), nil); err != ErrSecretKeyMissing {
+		t.Fatalf("expected ErrSecretKeyMissing, got %v", err)
+	}
+}
+
+func TestDecodeSecretValues_RoundTrip(t *testing.T) {
+	s := xorTestSecret{}
+
+	data := []byte(`
+top: value
+nested:
+  first: ` + EncryptedValuePrefix + xorEncryptHex("s3cr3t") + `
+  second: plain-value
+list:
+  - a
+  - ` + EncryptedValuePrefix + xorEncryptHex("listsecret") + `
+`)
+
+	decoded, err := decodeSecretValues(data, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := string(decoded)
+	for _, want := range []string{"s3cr3t", "plain-value", "listsecret", "top: value"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected rendered values to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestDecodeSecretValues_MissingKey(t *testing.T) {
+	data := []byte("key: " + EncryptedValuePrefix + "deadbeef\n")
+
+	if _, err := decodeSecretValues(data, nil); err == nil {
+		t.Fatal("expected an error when decoding a secret value without a key")
+	}
+}
+
+// TestDecodeSecretValues_PlainHexLikeValuesAreNotTreatedAsSecrets guards
+// against treating ordinary plain values that happen to look like hex
+// (a port number, a numeric ID, a git SHA) as ciphertext just because they
+// lack the encrypted: prefix — those must pass through untouched, even
+// without a secret.Secret available to decrypt anything.
+func TestDecodeSecretValues_PlainHexLikeValuesAreNotTreatedAsSecrets(t *testing.T) {
+	data := []byte(`
+port: "8080"
+shortId: "deadbeef"
+gitSha: "1234567890abcdef1234567890abcdef12345678"
+`)
+
+	decoded, err := decodeSecretValues(data, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := string(decoded)
+	for _, want := range []string{"8080", "deadbeef", "1234567890abcdef1234567890abcdef12345678"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected plain value %q to pass through untouched, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package deploy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/engine"
+	"k8s.io/helm/pkg/lint"
+	"k8s.io/helm/pkg/lint/support"
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/strvals"
+)
+
+// LintSeverity classifies a single LintMessage produced by Lint.
+type LintSeverity string
+
+const (
+	LintSeverityInfo    LintSeverity = "INFO"
+	LintSeverityWarning LintSeverity = "WARNING"
+	LintSeverityError   LintSeverity = "ERROR"
+)
+
+// LintMessage is a single finding from the in-process chart linter, relative
+// to ChartDir.
+type LintMessage struct {
+	Path     string
+	Severity LintSeverity
+	Message  string
+}
+
+// Render renders the chart's manifests in-process — the same
+// chartutil.Load/engine.Render path DeployHelmChart uses to produce
+// manifests before handing them to Tiller — using the accumulated Values
+// and Set overrides, and writes the concatenated YAML to out.
+func (chart *DappChart) Render(releaseName string, namespace string, opts HelmChartOptions, out io.Writer) error {
+	rendered, err := chart.renderManifests(releaseName, namespace, opts)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for path := range rendered {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if _, err := fmt.Fprintf(out, "---\n# Source: %s\n%s\n", path, rendered[path]); err != nil {
+			return fmt.Errorf("unable to write rendered manifest %s: %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+func (chart *DappChart) renderManifests(releaseName string, namespace string, opts HelmChartOptions) (map[string]string, error) {
+	chrt, err := chartutil.Load(chart.ChartDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load chart %s: %s", chart.ChartDir, err)
+	}
+
+	config, err := mergeHelmChartValues(append(chart.Values, opts.Values...), append(chart.Set, opts.Set...))
+	if err != nil {
+		return nil, err
+	}
+
+	renderValues, err := chartutil.ToRenderValues(chrt, config, chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: namespace,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build render values for %s: %s", chart.ChartDir, err)
+	}
+
+	rendered, err := engine.New().Render(chrt, renderValues)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render chart templates: %s", err)
+	}
+
+	return rendered, nil
+}
+
+// Lint runs the chart through helm's in-process linter — the same checks
+// `helm lint` runs, without shelling out to a helm binary — using the same
+// merged values as Render and Deploy.
+func (chart *DappChart) Lint(opts HelmChartOptions) ([]LintMessage, error) {
+	config, err := mergeHelmChartValues(append(chart.Values, opts.Values...), append(chart.Set, opts.Set...))
+	if err != nil {
+		return nil, err
+	}
+
+	linter := lint.All(chart.ChartDir, []byte(config.Raw), "default", false)
+
+	var messages []LintMessage
+	for _, msg := range linter.Messages {
+		messages = append(messages, LintMessage{
+			Path:     msg.Path,
+			Severity: lintSeverityOf(msg),
+			Message:  msg.Err.Error(),
+		})
+	}
+
+	return messages, nil
+}
+
+func lintSeverityOf(msg support.Message) LintSeverity {
+	switch msg.Severity {
+	case support.ErrorSev:
+		return LintSeverityError
+	case support.WarningSev:
+		return LintSeverityWarning
+	default:
+		return LintSeverityInfo
+	}
+}
+
+// mergeHelmChartValues reads the accumulated --values files in order and
+// layers --set overrides on top, the same merge order DeployHelmChart uses
+// to build the chart.Config it hands to Tiller.
+func mergeHelmChartValues(valuesFiles []string, setValues []string) (*helmchart.Config, error) {
+	merged := map[string]interface{}{}
+
+	for _, path := range valuesFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read values file %s: %s", path, err)
+		}
+
+		var layer map[string]interface{}
+		if err := yaml.Unmarshal(data, &layer); err != nil {
+			return nil, fmt.Errorf("unable to parse values file %s: %s", path, err)
+		}
+
+		merged = chartutil.CoalesceTables(layer, merged)
+	}
+
+	for _, set := range setValues {
+		if err := strvals.ParseInto(set, merged); err != nil {
+			return nil, fmt.Errorf("unable to parse --set %q: %s", set, err)
+		}
+	}
+
+	raw, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal merged values: %s", err)
+	}
+
+	return &helmchart.Config{Raw: string(raw)}, nil
+}
@@ -0,0 +1,134 @@
+// Package safepath helps join an untrusted relative path onto a trusted
+// root directory without letting symlinks or ".." components escape that
+// root, along the lines of moby's internal/safepath package.
+package safepath
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SafePath is a path that has been verified to resolve (with all symlinks
+// evaluated) to a location lexically contained within the root it was
+// joined against. The resolved parent directory is held open until Close;
+// SafePath exposes no operation through that FD, so callers reach the file
+// through Path, which is a second path lookup.
+type SafePath struct {
+	// Path is the resolved, symlink-free location on disk.
+	Path string
+
+	f *os.File
+}
+
+// Close releases any FD opened to keep the TOCTOU guarantee. It is safe to
+// call Close on a zero-value SafePath.
+func (p SafePath) Close() error {
+	if p.f == nil {
+		return nil
+	}
+	return p.f.Close()
+}
+
+// Join resolves unsafe (a path relative to root, possibly containing ".."
+// segments or symlinks) and guarantees that the result is lexically
+// contained under root once all symlinks have been evaluated. It rejects:
+//   - unsafe paths that are absolute,
+//   - unsafe paths that escape root via ".." components,
+//   - unsafe paths whose symlink chain (including the already-existing
+//     ancestors of root) resolves outside of root.
+//
+// The parent directory of the resolved path is opened and kept on the
+// returned SafePath until Close. Nothing is done through that FD: a caller
+// that stats or creates the final component does so by Path, so a
+// concurrent change between Join and that lookup is not detected.
+//
+// KNOWN GAP: Join does not and cannot detect a hardlink under root whose
+// inode is also linked to a path outside root — unlike a symlink, a
+// hardlinked directory entry has no separate "target path" to Lstat or
+// EvalSymlinks, so it is indistinguishable from an ordinary in-root file by
+// any path-based check. Closing this requires an inode/device (or Nlink>1)
+// check against the already-open file at the point data is written, which
+// Join does not do today. See TestJoin_KnownGap_DoesNotRejectHardlinksEscapingRoot.
+func Join(root, unsafe string) (SafePath, error) {
+	if filepath.IsAbs(unsafe) {
+		return SafePath{}, fmt.Errorf("unsafe path %q must be relative", unsafe)
+	}
+
+	evaledRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return SafePath{}, fmt.Errorf("unable to resolve root %q: %s", root, err)
+	}
+
+	joined := filepath.Join(evaledRoot, unsafe)
+	rel, err := filepath.Rel(evaledRoot, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return SafePath{}, fmt.Errorf("path %q escapes root %q", unsafe, root)
+	}
+
+	// Walk the ancestor directories one component at a time, resolving any
+	// symlink we encounter along the way. Unlike resolving filepath.Dir(joined)
+	// in one shot, this also catches a symlink further up the chain (e.g. the
+	// grandparent) escaping root, and lets us fail with a precise error the
+	// moment we hit a directory that does not exist yet, rather than silently
+	// falling back to an un-rooted, half-resolved parent.
+	resolvedParent := evaledRoot
+	if dir := filepath.Dir(rel); dir != "." {
+		for _, component := range strings.Split(dir, string(filepath.Separator)) {
+			candidate := filepath.Join(resolvedParent, component)
+
+			fi, statErr := os.Lstat(candidate)
+			if statErr != nil {
+				if os.IsNotExist(statErr) {
+					return SafePath{}, fmt.Errorf("unable to safely resolve %q: parent directory %q does not exist", unsafe, candidate)
+				}
+				return SafePath{}, fmt.Errorf("unable to stat %q: %s", candidate, statErr)
+			}
+
+			if fi.Mode()&os.ModeSymlink != 0 {
+				target, err := filepath.EvalSymlinks(candidate)
+				if err != nil {
+					return SafePath{}, fmt.Errorf("unable to resolve symlink %q: %s", candidate, err)
+				}
+				candidate = target
+			}
+
+			if !isLexicallyContained(evaledRoot, candidate) {
+				return SafePath{}, fmt.Errorf("path %q escapes root %q through a symlinked ancestor", unsafe, root)
+			}
+
+			resolvedParent = candidate
+		}
+	}
+
+	resolved := filepath.Join(resolvedParent, filepath.Base(joined))
+	if fi, err := os.Lstat(resolved); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(resolved)
+		if err != nil {
+			return SafePath{}, fmt.Errorf("unable to resolve symlink %q: %s", resolved, err)
+		}
+		if !isLexicallyContained(evaledRoot, target) {
+			return SafePath{}, fmt.Errorf("symlink %q points outside of root %q", unsafe, root)
+		}
+		resolved = target
+	}
+
+	f, err := os.Open(resolvedParent)
+	if err != nil {
+		return SafePath{}, fmt.Errorf("unable to open %q: %s", resolvedParent, err)
+	}
+
+	return SafePath{Path: resolved, f: f}, nil
+}
+
+func isLexicallyContained(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	if rel == "." {
+		return true
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
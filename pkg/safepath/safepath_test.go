@@ -0,0 +1,145 @@
+package safepath
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJoin_PlainRelativePath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "file.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := Join(root, "file.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sp.Close()
+
+	if sp.Path != filepath.Join(root, "file.txt") {
+		t.Fatalf("unexpected resolved path: %s", sp.Path)
+	}
+}
+
+func TestJoin_RejectsDotDotTraversal(t *testing.T) {
+	root := t.TempDir()
+
+	if _, err := Join(root, "../etc/passwd"); err == nil {
+		t.Fatal("expected an error for a path escaping root via ..")
+	}
+}
+
+func TestJoin_RejectsAbsolutePath(t *testing.T) {
+	root := t.TempDir()
+
+	if _, err := Join(root, "/etc/passwd"); err == nil {
+		t.Fatal("expected an error for an absolute unsafe path")
+	}
+}
+
+func TestJoin_RejectsSymlinkEscapingRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("nope"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(root, "escape")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Join(root, "escape/secret.txt"); err == nil {
+		t.Fatal("expected an error for a symlink escaping root")
+	}
+}
+
+func TestJoin_AllowsSymlinkWithinRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "real"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "real", "file.txt"), []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := Join(root, "link/file.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sp.Close()
+
+	want, err := filepath.EvalSymlinks(filepath.Join(root, "real", "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.Path != want {
+		t.Fatalf("got %s, want %s", sp.Path, want)
+	}
+}
+
+// TestJoin_KnownGap_DoesNotRejectHardlinksEscapingRoot is a regression
+// pinned to a real, unresolved gap, not a statement that this is working as
+// intended: Join is a path-based check, and a hardlink has no separate
+// "target path" the way a symlink does — the directory entry under root
+// simply IS a name for the shared inode, so there is nothing to Lstat or
+// EvalSymlinks that would reveal the other, outside-root, name for the same
+// inode. Join currently lets this through. Closing it needs a check at the
+// point data is actually written through the open *os.File — e.g. comparing
+// Stat().Sys().(*syscall.Stat_t).{Dev,Ino} against a freshly opened copy of
+// root, or rejecting Nlink > 1 outright — which Join does not do today; see
+// the KNOWN GAP note on Join's doc comment in safepath.go.
+func TestJoin_KnownGap_DoesNotRejectHardlinksEscapingRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	outsideFile := filepath.Join(outside, "data")
+	if err := os.WriteFile(outsideFile, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(root, "hardlink")
+	if err := os.Link(outsideFile, link); err != nil {
+		t.Skipf("hardlinks not supported on this filesystem: %s", err)
+	}
+
+	sp, err := Join(root, "hardlink")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sp.Close()
+
+	if sp.Path != link {
+		t.Fatalf("expected hardlink to resolve to its in-root path, got %s", sp.Path)
+	}
+}
+
+func TestJoin_ErrorsClearlyWhenAncestorDirDoesNotExist(t *testing.T) {
+	root := t.TempDir()
+
+	_, err := Join(root, "no-such-dir/file.txt")
+	if err == nil {
+		t.Fatal("expected an error when an intermediate directory does not exist")
+	}
+	if !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected a clear does-not-exist error, got: %s", err)
+	}
+}
+
+func TestJoin_ErrorsClearlyWhenDeepAncestorDirDoesNotExist(t *testing.T) {
+	root := t.TempDir()
+
+	_, err := Join(root, "a/b/c/file.txt")
+	if err == nil {
+		t.Fatal("expected an error when a deep intermediate directory does not exist")
+	}
+	if !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected a clear does-not-exist error, got: %s", err)
+	}
+}